@@ -0,0 +1,54 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+)
+
+// tagOptions is the list of comma-separated options in a struct tag,
+// excluding the leading alias.
+type tagOptions []string
+
+// Contains reports whether option is present verbatim in o.
+func (o tagOptions) Contains(option string) bool {
+	for _, s := range o {
+		if s == option {
+			return true
+		}
+	}
+	return false
+}
+
+// value returns the value of a "key:value" option, if present.
+func (o tagOptions) value(key string) (string, bool) {
+	prefix := key + ":"
+	for _, s := range o {
+		if strings.HasPrefix(s, prefix) {
+			return strings.TrimPrefix(s, prefix), true
+		}
+	}
+	return "", false
+}
+
+// cache holds the struct tag name shared by an Encoder/Decoder.
+type cache struct {
+	tag string
+}
+
+func newCache() *cache {
+	return &cache{tag: "schema"}
+}
+
+// fieldAlias returns the alias and tag options for f, as found under tagName.
+// If no alias is set, the field's own name is used.
+func fieldAlias(f reflect.StructField, tagName string) (alias string, options tagOptions) {
+	if tag := f.Tag.Get(tagName); tag != "" {
+		parts := strings.Split(tag, ",")
+		alias = parts[0]
+		options = tagOptions(parts[1:])
+	}
+	if alias == "" {
+		alias = f.Name
+	}
+	return alias, options
+}
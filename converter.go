@@ -0,0 +1,111 @@
+package schema
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// convertTextUnmarshaler builds a Converter that decodes into t via a
+// pointer to t's encoding.TextUnmarshaler implementation.
+func convertTextUnmarshaler(t reflect.Type) Converter {
+	return func(value string) reflect.Value {
+		ptr := reflect.New(t)
+		if err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value)); err != nil {
+			return reflect.Value{}
+		}
+		return ptr.Elem()
+	}
+}
+
+// Converter converts a string into a reflect.Value of the destination type.
+// It returns the zero Value if the conversion fails.
+type Converter func(string) reflect.Value
+
+func convertBool(value string) reflect.Value {
+	v, err := strconv.ParseBool(value)
+	if err != nil {
+		return reflect.Value{}
+	}
+	return reflect.ValueOf(v)
+}
+
+func convertInt(bitSize int) Converter {
+	return func(value string) reflect.Value {
+		v, err := strconv.ParseInt(value, 10, bitSize)
+		if err != nil {
+			return reflect.Value{}
+		}
+		switch bitSize {
+		case 8:
+			return reflect.ValueOf(int8(v))
+		case 16:
+			return reflect.ValueOf(int16(v))
+		case 32:
+			return reflect.ValueOf(int32(v))
+		case 64:
+			return reflect.ValueOf(v)
+		default:
+			return reflect.ValueOf(int(v))
+		}
+	}
+}
+
+func convertUint(bitSize int) Converter {
+	return func(value string) reflect.Value {
+		v, err := strconv.ParseUint(value, 10, bitSize)
+		if err != nil {
+			return reflect.Value{}
+		}
+		switch bitSize {
+		case 8:
+			return reflect.ValueOf(uint8(v))
+		case 16:
+			return reflect.ValueOf(uint16(v))
+		case 32:
+			return reflect.ValueOf(uint32(v))
+		case 64:
+			return reflect.ValueOf(v)
+		default:
+			return reflect.ValueOf(uint(v))
+		}
+	}
+}
+
+func convertFloat(bitSize int) Converter {
+	return func(value string) reflect.Value {
+		v, err := strconv.ParseFloat(value, bitSize)
+		if err != nil {
+			return reflect.Value{}
+		}
+		if bitSize == 32 {
+			return reflect.ValueOf(float32(v))
+		}
+		return reflect.ValueOf(v)
+	}
+}
+
+func convertString(value string) reflect.Value {
+	return reflect.ValueOf(value)
+}
+
+// builtinConverters maps reflect.Kind to the Converter used when no
+// user-registered converter applies.
+var builtinConverters = map[reflect.Kind]Converter{
+	reflect.Bool:    convertBool,
+	reflect.Int:     convertInt(0),
+	reflect.Int8:    convertInt(8),
+	reflect.Int16:   convertInt(16),
+	reflect.Int32:   convertInt(32),
+	reflect.Int64:   convertInt(64),
+	reflect.Uint:    convertUint(0),
+	reflect.Uint8:   convertUint(8),
+	reflect.Uint16:  convertUint(16),
+	reflect.Uint32:  convertUint(32),
+	reflect.Uint64:  convertUint(64),
+	reflect.Float32: convertFloat(32),
+	reflect.Float64: convertFloat(64),
+	reflect.String:  convertString,
+}
@@ -0,0 +1,91 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type upperString string
+
+func (u *upperString) UnmarshalText(text []byte) error {
+	*u = upperString(strings.ToUpper(string(text)))
+	return nil
+}
+
+func TestDecodeUseTextUnmarshal(t *testing.T) {
+	type S struct {
+		Name upperString `schema:"name"`
+	}
+
+	d := NewDecoder()
+	d.UseTextUnmarshal(true)
+
+	var s S
+	if err := d.Decode(&s, map[string][]string{"name": {"hi"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Name != "HI" {
+		t.Errorf("Name = %q, want %q", s.Name, "HI")
+	}
+}
+
+func TestDecodeUseTextUnmarshalDisabledByDefault(t *testing.T) {
+	type S struct {
+		Name upperString `schema:"name"`
+	}
+
+	var s S
+	err := NewDecoder().Decode(&s, map[string][]string{"name": {"hi"}})
+	if err == nil {
+		t.Fatal("expected an error when UseTextUnmarshal is not enabled")
+	}
+}
+
+// stamp is a struct-kind type (like time.Time) that only decodes correctly
+// as a single value (through UnmarshalText or a registered Converter),
+// never by recursing into its unexported field.
+type stamp struct {
+	raw string
+}
+
+func (s *stamp) UnmarshalText(text []byte) error {
+	s.raw = string(text)
+	return nil
+}
+
+func TestDecodeUseTextUnmarshalOnStructField(t *testing.T) {
+	type S struct {
+		When stamp `schema:"when"`
+	}
+
+	d := NewDecoder()
+	d.UseTextUnmarshal(true)
+
+	var s S
+	if err := d.Decode(&s, map[string][]string{"when": {"2020-01-02T15:04:05Z"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.When.raw != "2020-01-02T15:04:05Z" {
+		t.Errorf("When.raw = %q, want %q", s.When.raw, "2020-01-02T15:04:05Z")
+	}
+}
+
+func TestDecodeRegisterConverterOnStructField(t *testing.T) {
+	type S struct {
+		When stamp `schema:"when"`
+	}
+
+	d := NewDecoder()
+	d.RegisterConverter(stamp{}, func(value string) reflect.Value {
+		return reflect.ValueOf(stamp{raw: value})
+	})
+
+	var s S
+	if err := d.Decode(&s, map[string][]string{"when": {"registered"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.When.raw != "registered" {
+		t.Errorf("When.raw = %q, want %q", s.When.raw, "registered")
+	}
+}
@@ -0,0 +1,290 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// decodePlan is the precomputed description of one struct field used by
+// Decoder.decode, so that Decode need not re-walk struct tags or
+// re-resolve Converters on every call for a previously seen type.
+type decodePlan struct {
+	index    int
+	name     string
+	opts     tagOptions
+	isStruct bool
+	isSlice  bool
+	elemType reflect.Type
+	conv     Converter
+}
+
+// Decoder decodes values from a map[string][]string into a struct.
+type Decoder struct {
+	cache             *cache
+	regconv           map[reflect.Type]Converter
+	ignoreUnknownKeys bool
+	useTextUnmarshal  bool
+	nesting           NestingStyle
+
+	plansMu sync.RWMutex
+	plans   map[reflect.Type][]decodePlan
+}
+
+// NewDecoder returns a new Decoder with defaults.
+func NewDecoder() *Decoder {
+	return &Decoder{
+		cache:   newCache(),
+		regconv: make(map[reflect.Type]Converter),
+		plans:   make(map[reflect.Type][]decodePlan),
+	}
+}
+
+// Precompile walks the struct type of each of types and caches its field
+// plan, so the first real Decode call for that type does not pay the
+// cost of building it. Safe to call from multiple goroutines.
+func (d *Decoder) Precompile(types ...interface{}) {
+	for _, t := range types {
+		v := reflect.ValueOf(t)
+		if v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		d.planFor(v.Type())
+	}
+}
+
+// IgnoreUnknownKeys controls whether unrecognized keys in the source map
+// cause Decode to fail. The default is false.
+func (d *Decoder) IgnoreUnknownKeys(i bool) {
+	d.ignoreUnknownKeys = i
+}
+
+// SetAliasTag changes the tag used to locate custom field aliases.
+// The default tag is "schema".
+func (d *Decoder) SetAliasTag(tag string) {
+	d.plansMu.Lock()
+	defer d.plansMu.Unlock()
+	d.cache.tag = tag
+	d.resetPlansLocked()
+}
+
+// SetNestingStyle changes how nested struct fields are looked up in the
+// source map. The default is NestFlat, and must match whatever style
+// produced src (see Encoder.SetNestingStyle) for nested fields to decode.
+func (d *Decoder) SetNestingStyle(style NestingStyle) {
+	d.nesting = style
+}
+
+// UseTextUnmarshal controls the behaviour when the decoder encounters
+// fields whose type implements encoding.TextUnmarshaler.
+// If u is true, UnmarshalText is preferred over the built-in converters.
+//
+// To preserve backwards compatibility, the default value is false.
+func (d *Decoder) UseTextUnmarshal(u bool) {
+	d.plansMu.Lock()
+	defer d.plansMu.Unlock()
+	d.useTextUnmarshal = u
+	d.resetPlansLocked()
+}
+
+// RegisterConverter registers a converter for decoding a custom type.
+func (d *Decoder) RegisterConverter(value interface{}, converter Converter) {
+	d.plansMu.Lock()
+	defer d.plansMu.Unlock()
+	d.regconv[reflect.TypeOf(value)] = converter
+	d.resetPlansLocked()
+}
+
+// resetPlansLocked drops any cached field plans. Called whenever a
+// setting that a plan's Converters close over changes, so
+// Precompile/Decode pick up the new behaviour instead of stale cached
+// converters. Callers must hold plansMu.
+func (d *Decoder) resetPlansLocked() {
+	d.plans = make(map[reflect.Type][]decodePlan)
+}
+
+// Decode decodes a map[string][]string into a struct.
+//
+// Intended for use with url.Values.
+//
+// Fields that are absent or empty in src are left at their zero value
+// unless the field's tag carries a "default:" option, e.g.
+// `schema:"foo,default:hello"`. For slice fields, the default value is
+// split on "|", e.g. `schema:"foos,default:hello|world"`. A "required"
+// option without a matching default still produces an error when the
+// field is missing.
+func (d *Decoder) Decode(dst interface{}, src map[string][]string) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return errors.New("schema: interface must be a pointer to struct")
+	}
+
+	errs := MultiError{}
+	if err := d.decode(v.Elem(), "", src); err != nil {
+		for name, fieldErr := range err.(MultiError) {
+			errs[name] = fieldErr
+		}
+	}
+
+	if !d.ignoreUnknownKeys {
+		known := make(map[string]struct{})
+		d.collectNames(v.Elem().Type(), "", known)
+		for key := range src {
+			if _, ok := known[key]; !ok {
+				errs[key] = fmt.Errorf("schema: unknown key %q", key)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// collectNames records, in out, the key every non-struct field of t
+// decodes under, recursing into nested structs the same way decode
+// does, so Decode can tell a genuinely unknown src key from a known one.
+func (d *Decoder) collectNames(t reflect.Type, prefix string, out map[string]struct{}) {
+	for _, fp := range d.planFor(t) {
+		name := nestedName(d.nesting, prefix, fp.name)
+		if fp.isStruct {
+			d.collectNames(t.Field(fp.index).Type, name, out)
+			continue
+		}
+		out[name] = struct{}{}
+	}
+}
+
+func (d *Decoder) decode(v reflect.Value, prefix string, src map[string][]string) error {
+	plan := d.planFor(v.Type())
+	errs := MultiError{}
+
+	for _, fp := range plan {
+		fv := v.Field(fp.index)
+		name := nestedName(d.nesting, prefix, fp.name)
+
+		if fp.isStruct {
+			if err := d.decode(fv, name, src); err != nil {
+				errs[name] = err
+			}
+			continue
+		}
+
+		values, ok := src[name]
+		empty := !ok || len(values) == 0 || (len(values) == 1 && values[0] == "")
+
+		if empty {
+			def, hasDefault := fp.opts.value("default")
+			switch {
+			case hasDefault && fp.isSlice:
+				values = strings.Split(def, "|")
+			case hasDefault:
+				values = []string{def}
+			case fp.opts.Contains("required"):
+				errs[name] = fmt.Errorf("schema: %q is required", name)
+				continue
+			default:
+				continue
+			}
+		}
+
+		if fp.conv == nil {
+			elemOrField := fv.Type()
+			if fp.isSlice {
+				elemOrField = fp.elemType
+			}
+			errs[name] = fmt.Errorf("schema: converter not found for %v", elemOrField)
+			continue
+		}
+
+		if fp.isSlice {
+			slice := reflect.MakeSlice(fv.Type(), 0, len(values))
+			for _, raw := range values {
+				val := fp.conv(raw)
+				if !val.IsValid() || val.Type() != fp.elemType {
+					errs[name] = fmt.Errorf("schema: invalid value %q for %v", raw, fp.elemType)
+					continue
+				}
+				slice = reflect.Append(slice, val)
+			}
+			fv.Set(slice)
+			continue
+		}
+
+		val := fp.conv(values[0])
+		if !val.IsValid() || val.Type() != fv.Type() {
+			errs[name] = fmt.Errorf("schema: invalid value %q for %v", values[0], fv.Type())
+			continue
+		}
+		fv.Set(val)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// planFor returns the cached decodePlan for t, building and caching it
+// if this is the first time t has been seen.
+func (d *Decoder) planFor(t reflect.Type) []decodePlan {
+	d.plansMu.RLock()
+	plan, ok := d.plans[t]
+	d.plansMu.RUnlock()
+	if ok {
+		return plan
+	}
+
+	d.plansMu.Lock()
+	defer d.plansMu.Unlock()
+	if plan, ok := d.plans[t]; ok {
+		return plan
+	}
+
+	plan = make([]decodePlan, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, opts := fieldAlias(t.Field(i), d.cache.tag)
+		if name == "-" {
+			continue
+		}
+
+		fp := decodePlan{index: i, name: name, opts: opts}
+		ft := t.Field(i).Type
+
+		switch ft.Kind() {
+		case reflect.Slice:
+			fp.isSlice = true
+			fp.elemType = ft.Elem()
+			fp.conv = d.converterFor(fp.elemType)
+		case reflect.Struct:
+			// A registered converter or UseTextUnmarshal takes priority
+			// over Kind-based recursion, e.g. for time.Time/uuid.UUID-
+			// style structs that decode as a single string, matching how
+			// Encoder.planFor prioritizes encFunc over struct recursion.
+			if conv := d.converterFor(ft); conv != nil {
+				fp.conv = conv
+			} else {
+				fp.isStruct = true
+			}
+		default:
+			fp.conv = d.converterFor(ft)
+		}
+
+		plan = append(plan, fp)
+	}
+	d.plans[t] = plan
+	return plan
+}
+
+func (d *Decoder) converterFor(t reflect.Type) Converter {
+	if c, ok := d.regconv[t]; ok {
+		return c
+	}
+	if d.useTextUnmarshal && reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return convertTextUnmarshaler(t)
+	}
+	return builtinConverters[t.Kind()]
+}
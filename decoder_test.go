@@ -0,0 +1,77 @@
+package schema
+
+import "testing"
+
+func TestDecodeDefault(t *testing.T) {
+	type S struct {
+		Foo  string   `schema:"foo,default:hello"`
+		Foos []string `schema:"foos,default:hello|world"`
+	}
+
+	var s S
+	if err := NewDecoder().Decode(&s, map[string][]string{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Foo != "hello" {
+		t.Errorf("Foo = %q, want %q", s.Foo, "hello")
+	}
+	if len(s.Foos) != 2 || s.Foos[0] != "hello" || s.Foos[1] != "world" {
+		t.Errorf("Foos = %v, want [hello world]", s.Foos)
+	}
+}
+
+func TestDecodeDefaultOverridden(t *testing.T) {
+	type S struct {
+		Foo string `schema:"foo,default:hello"`
+	}
+
+	var s S
+	src := map[string][]string{"foo": {"bye"}}
+	if err := NewDecoder().Decode(&s, src); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Foo != "bye" {
+		t.Errorf("Foo = %q, want %q", s.Foo, "bye")
+	}
+}
+
+func TestDecodeRequiredWithoutDefault(t *testing.T) {
+	type S struct {
+		Foo string `schema:"foo,required"`
+	}
+
+	var s S
+	if err := NewDecoder().Decode(&s, map[string][]string{}); err == nil {
+		t.Fatal("expected an error for missing required field")
+	}
+}
+
+func TestDecodeUnknownKeyFailsByDefault(t *testing.T) {
+	type S struct {
+		Foo string `schema:"foo"`
+	}
+
+	var s S
+	src := map[string][]string{"foo": {"hi"}, "bar": {"unexpected"}}
+	if err := NewDecoder().Decode(&s, src); err == nil {
+		t.Fatal("expected an error for an unknown key")
+	}
+}
+
+func TestDecodeIgnoreUnknownKeys(t *testing.T) {
+	type S struct {
+		Foo string `schema:"foo"`
+	}
+
+	d := NewDecoder()
+	d.IgnoreUnknownKeys(true)
+
+	var s S
+	src := map[string][]string{"foo": {"hi"}, "bar": {"unexpected"}}
+	if err := d.Decode(&s, src); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Foo != "hi" {
+		t.Errorf("Foo = %q, want %q", s.Foo, "hi")
+	}
+}
@@ -6,20 +6,111 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"sync"
 )
 
 type encoderFunc func(reflect.Value) (string, error)
 
+// fieldPlan is the precomputed description of one struct field used by
+// Encoder.encode, so that Encode need not re-walk struct tags or
+// re-resolve encoderFuncs on every call for a previously seen type.
+type fieldPlan struct {
+	index       int
+	name        string
+	opts        tagOptions
+	encFunc     encoderFunc // set for scalar/custom/registered fields, and for nil struct pointers
+	elemFunc    encoderFunc // set when isSlice
+	isStructPtr bool
+	isStruct    bool
+	isSlice     bool
+}
+
 // Encoder encodes values from a struct into url.Values.
 type Encoder struct {
 	cache          *cache
 	regenc         map[reflect.Type]encoderFunc
 	useTextMarshal bool
+	nesting        NestingStyle
+	floatFmt       byte
+	floatPrec      int
+
+	plansMu sync.RWMutex
+	plans   map[reflect.Type][]fieldPlan
 }
 
 // NewEncoder returns a new Encoder with defaults.
 func NewEncoder() *Encoder {
-	return &Encoder{cache: newCache(), regenc: make(map[reflect.Type]encoderFunc)}
+	return &Encoder{
+		cache:     newCache(),
+		regenc:    make(map[reflect.Type]encoderFunc),
+		floatFmt:  'f',
+		floatPrec: 6,
+		plans:     make(map[reflect.Type][]fieldPlan),
+	}
+}
+
+// Precompile walks the struct type of each of types and caches its field
+// plan, so the first real Encode call for that type does not pay the
+// cost of building it. Safe to call from multiple goroutines.
+func (e *Encoder) Precompile(types ...interface{}) {
+	for _, t := range types {
+		v := reflect.ValueOf(t)
+		if v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		e.planFor(v.Type())
+	}
+}
+
+// planFor returns the cached fieldPlan for t, building and caching it if
+// this is the first time t has been seen.
+func (e *Encoder) planFor(t reflect.Type) []fieldPlan {
+	e.plansMu.RLock()
+	plan, ok := e.plans[t]
+	e.plansMu.RUnlock()
+	if ok {
+		return plan
+	}
+
+	e.plansMu.Lock()
+	defer e.plansMu.Unlock()
+	if plan, ok := e.plans[t]; ok {
+		return plan
+	}
+
+	plan = make([]fieldPlan, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, opts := fieldAlias(t.Field(i), e.cache.tag)
+		if name == "-" {
+			continue
+		}
+
+		fp := fieldPlan{index: i, name: name, opts: opts}
+		ft := t.Field(i).Type
+
+		switch {
+		case ft.Kind() == reflect.Ptr && ft.Elem().Kind() == reflect.Struct:
+			// A non-nil value recurses (handled in encode); a nil one
+			// falls back to encFunc, which renders "null".
+			fp.isStructPtr = true
+			fp.encFunc = e.typeEncoder(ft, e.regenc, opts)
+		default:
+			fp.encFunc = e.typeEncoder(ft, e.regenc, opts)
+			if fp.encFunc == nil {
+				switch ft.Kind() {
+				case reflect.Struct:
+					fp.isStruct = true
+				case reflect.Slice:
+					fp.isSlice = true
+					fp.elemFunc = e.typeEncoder(ft.Elem(), e.regenc, opts)
+				}
+			}
+		}
+
+		plan = append(plan, fp)
+	}
+	e.plans[t] = plan
+	return plan
 }
 
 // UseTextMarshal controls the behaviour when the decoder encounters values
@@ -28,7 +119,10 @@ func NewEncoder() *Encoder {
 //
 // To preserve backwards compatibility, the default value is false.
 func (e *Encoder) UseTextMarshal(u bool) {
+	e.plansMu.Lock()
+	defer e.plansMu.Unlock()
 	e.useTextMarshal = u
+	e.resetPlansLocked()
 }
 
 // Encode encodes a struct into map[string][]string.
@@ -37,20 +131,55 @@ func (e *Encoder) UseTextMarshal(u bool) {
 func (e *Encoder) Encode(src interface{}, dst map[string][]string) error {
 	v := reflect.ValueOf(src)
 
-	return e.encode(v, dst)
+	return e.encode(v, "", dst)
 }
 
 // RegisterEncoder registers a converter for encoding a custom type.
 func (e *Encoder) RegisterEncoder(value interface{}, encoder func(reflect.Value) string) {
+	e.plansMu.Lock()
+	defer e.plansMu.Unlock()
 	e.regenc[reflect.TypeOf(value)] = func(v reflect.Value) (string, error) {
 		return encoder(v), nil
 	}
+	e.resetPlansLocked()
 }
 
 // SetAliasTag changes the tag used to locate custom field aliases.
 // The default tag is "schema".
 func (e *Encoder) SetAliasTag(tag string) {
+	e.plansMu.Lock()
+	defer e.plansMu.Unlock()
 	e.cache.tag = tag
+	e.resetPlansLocked()
+}
+
+// SetNestingStyle changes how nested struct fields are represented as
+// keys. The default is NestFlat.
+func (e *Encoder) SetNestingStyle(style NestingStyle) {
+	e.nesting = style
+}
+
+// SetFloatFormat changes the default strconv.FormatFloat format and
+// precision used to encode float32/float64 fields. The default is
+// ('f', 6). A field's "fmt:" and "prec:" tag options, e.g.
+// `schema:"price,fmt:g,prec:-1"`, override this per field; prec:-1 gives
+// the shortest representation that round-trips. Output is that of
+// strconv.FormatFloat, so exponent forms (e.g. "1e-07") are not rewritten
+// the way json.Marshal writes them.
+func (e *Encoder) SetFloatFormat(format byte, prec int) {
+	e.plansMu.Lock()
+	defer e.plansMu.Unlock()
+	e.floatFmt = format
+	e.floatPrec = prec
+	e.resetPlansLocked()
+}
+
+// resetPlansLocked drops any cached field plans. Called whenever a
+// setting that a plan's encoderFuncs close over changes, so
+// Precompile/Encode pick up the new behaviour instead of stale cached
+// funcs. Callers must hold plansMu.
+func (e *Encoder) resetPlansLocked() {
+	e.plans = make(map[reflect.Type][]fieldPlan)
 }
 
 // isValidStructPointer test if input value is a valid struct pointer.
@@ -58,7 +187,25 @@ func isValidStructPointer(v reflect.Value) bool {
 	return v.Type().Kind() == reflect.Ptr && v.Elem().IsValid() && v.Elem().Type().Kind() == reflect.Struct
 }
 
+// Zeroer is implemented by types that can report their own emptiness for
+// the purposes of "omitempty" encoding, such as time.Time or a custom
+// nullable wrapper. When a value's type implements it, isZero calls
+// IsZero instead of its reflect-based comparison, which misclassifies such
+// types as non-empty.
+type Zeroer interface {
+	IsZero() bool
+}
+
+var zeroerType = reflect.TypeOf((*Zeroer)(nil)).Elem()
+
 func isZero(v reflect.Value) bool {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return true
+	}
+	if v.Type().Implements(zeroerType) {
+		return v.Interface().(Zeroer).IsZero()
+	}
+
 	switch v.Kind() {
 	case reflect.Func:
 	case reflect.Map, reflect.Slice:
@@ -81,38 +228,50 @@ func isZero(v reflect.Value) bool {
 	return v.Interface() == z.Interface()
 }
 
-func (e *Encoder) encode(v reflect.Value, dst map[string][]string) error {
+func (e *Encoder) encode(v reflect.Value, prefix string, dst map[string][]string) error {
 	if v.Kind() == reflect.Ptr {
 		v = v.Elem()
 	}
 	if v.Kind() != reflect.Struct {
 		return errors.New("schema: interface must be a struct")
 	}
-	t := v.Type()
 
-	errors := MultiError{}
+	plan := e.planFor(v.Type())
+	errs := MultiError{}
 
-	for i := 0; i < v.NumField(); i++ {
-		name, opts := fieldAlias(t.Field(i), e.cache.tag)
-		if name == "-" {
-			continue
-		}
+	for _, fp := range plan {
+		fv := v.Field(fp.index)
+		name := nestedName(e.nesting, prefix, fp.name)
 
 		// Encode struct pointer types if the field is a valid pointer and a struct.
-		if isValidStructPointer(v.Field(i)) {
-			e.encode(v.Field(i).Elem(), dst)
+		if fp.isStructPtr {
+			if isValidStructPointer(fv) {
+				e.encode(fv.Elem(), name, dst)
+				continue
+			}
+			value, err := fp.encFunc(fv)
+			if err != nil {
+				errs[fv.Type().String()] = fmt.Errorf("schema: failed to encode field: %s", err)
+			}
+			dst[name] = append(dst[name], value)
 			continue
 		}
 
-		encFunc := e.typeEncoder(v.Field(i).Type(), e.regenc)
+		if fp.isStruct {
+			if fp.opts.Contains("omitempty") && isZero(fv) {
+				continue
+			}
+			e.encode(fv, name, dst)
+			continue
+		}
 
 		// Encode non-slice types and custom implementations immediately.
-		if encFunc != nil {
-			value, err := encFunc(v.Field(i))
+		if fp.encFunc != nil {
+			value, err := fp.encFunc(fv)
 			if err != nil {
-				errors[v.Field(i).Type().String()] = fmt.Errorf("schema: failed to encode field: %s", err)
+				errs[fv.Type().String()] = fmt.Errorf("schema: failed to encode field: %s", err)
 			}
-			if opts.Contains("omitempty") && isZero(v.Field(i)) {
+			if fp.opts.Contains("omitempty") && isZero(fv) {
 				continue
 			}
 
@@ -120,43 +279,34 @@ func (e *Encoder) encode(v reflect.Value, dst map[string][]string) error {
 			continue
 		}
 
-		if v.Field(i).Type().Kind() == reflect.Struct {
-			e.encode(v.Field(i), dst)
-			continue
-		}
-
-		if v.Field(i).Type().Kind() == reflect.Slice {
-			encFunc = e.typeEncoder(v.Field(i).Type().Elem(), e.regenc)
-		}
-
-		if encFunc == nil {
-			errors[v.Field(i).Type().String()] = fmt.Errorf("schema: encoder not found for %v", v.Field(i))
+		if !fp.isSlice || fp.elemFunc == nil {
+			errs[fv.Type().String()] = fmt.Errorf("schema: encoder not found for %v", fv)
 			continue
 		}
 
 		// Encode a slice.
-		if v.Field(i).Len() == 0 && opts.Contains("omitempty") {
+		if fv.Len() == 0 && fp.opts.Contains("omitempty") {
 			continue
 		}
 
 		dst[name] = []string{}
-		for j := 0; j < v.Field(i).Len(); j++ {
-			value, err := encFunc(v.Field(i).Index(j))
+		for j := 0; j < fv.Len(); j++ {
+			value, err := fp.elemFunc(fv.Index(j))
 			if err != nil {
-				errors[v.Field(i).Type().String()] = fmt.Errorf("schema: failed to encode slice element: %s", err)
+				errs[fv.Type().String()] = fmt.Errorf("schema: failed to encode slice element: %s", err)
 				continue
 			}
 			dst[name] = append(dst[name], value)
 		}
 	}
 
-	if len(errors) > 0 {
-		return errors
+	if len(errs) > 0 {
+		return errs
 	}
 	return nil
 }
 
-func (e *Encoder) typeEncoder(t reflect.Type, reg map[reflect.Type]encoderFunc) encoderFunc {
+func (e *Encoder) typeEncoder(t reflect.Type, reg map[reflect.Type]encoderFunc, opts tagOptions) encoderFunc {
 	if f, ok := reg[t]; ok {
 		return f
 	}
@@ -169,15 +319,17 @@ func (e *Encoder) typeEncoder(t reflect.Type, reg map[reflect.Type]encoderFunc)
 	case reflect.Bool:
 		return encodeBool
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return encodeInt
+		base, err := intBase(opts)
+		return encodeInt(base, err)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return encodeUint
+		base, err := intBase(opts)
+		return encodeUint(base, err)
 	case reflect.Float32:
-		return encodeFloat32
+		return e.encodeFloat(32, opts)
 	case reflect.Float64:
-		return encodeFloat64
+		return e.encodeFloat(64, opts)
 	case reflect.Ptr:
-		f := e.typeEncoder(t.Elem(), reg)
+		f := e.typeEncoder(t.Elem(), reg, opts)
 		return func(v reflect.Value) (string, error) {
 			if v.IsNil() {
 				return "null", nil
@@ -191,6 +343,25 @@ func (e *Encoder) typeEncoder(t reflect.Type, reg map[reflect.Type]encoderFunc)
 	}
 }
 
+// intBase returns the integer base for a field, from its "base:" tag
+// option (e.g. `schema:"mask,base:16"`), defaulting to 10. strconv only
+// accepts bases 2 through 36, so an out-of-range or unparsable base is
+// reported as an error rather than left to panic in FormatInt/FormatUint.
+func intBase(opts tagOptions) (int, error) {
+	b, ok := opts.value("base")
+	if !ok {
+		return 10, nil
+	}
+	base, err := strconv.Atoi(b)
+	if err != nil {
+		return 0, fmt.Errorf("invalid base %q: %s", b, err)
+	}
+	if base < 2 || base > 36 {
+		return 0, fmt.Errorf("invalid base %q: must be between 2 and 36", b)
+	}
+	return base, nil
+}
+
 func encodeTextMarshaler(v reflect.Value) (string, error) {
 	text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
 	if err != nil {
@@ -204,24 +375,40 @@ func encodeBool(v reflect.Value) (string, error) {
 	return strconv.FormatBool(v.Bool()), nil
 }
 
-func encodeInt(v reflect.Value) (string, error) {
-	return strconv.FormatInt(int64(v.Int()), 10), nil
-}
-
-func encodeUint(v reflect.Value) (string, error) {
-	return strconv.FormatUint(uint64(v.Uint()), 10), nil
-}
-
-func encodeFloat(v reflect.Value, bits int) (string, error) {
-	return strconv.FormatFloat(v.Float(), 'f', 6, bits), nil
+func encodeInt(base int, err error) encoderFunc {
+	if err != nil {
+		return func(reflect.Value) (string, error) { return "", err }
+	}
+	return func(v reflect.Value) (string, error) {
+		return strconv.FormatInt(v.Int(), base), nil
+	}
 }
 
-func encodeFloat32(v reflect.Value) (string, error) {
-	return encodeFloat(v, 32)
+func encodeUint(base int, err error) encoderFunc {
+	if err != nil {
+		return func(reflect.Value) (string, error) { return "", err }
+	}
+	return func(v reflect.Value) (string, error) {
+		return strconv.FormatUint(v.Uint(), base), nil
+	}
 }
 
-func encodeFloat64(v reflect.Value) (string, error) {
-	return encodeFloat(v, 64)
+// encodeFloat returns an encoderFunc for a float32/float64 field, using
+// the field's "fmt:"/"prec:" tag options when present and falling back
+// to the Encoder's global SetFloatFormat settings otherwise.
+func (e *Encoder) encodeFloat(bits int, opts tagOptions) encoderFunc {
+	format, prec := e.floatFmt, e.floatPrec
+	if f, ok := opts.value("fmt"); ok && len(f) == 1 {
+		format = f[0]
+	}
+	if p, ok := opts.value("prec"); ok {
+		if parsed, err := strconv.Atoi(p); err == nil {
+			prec = parsed
+		}
+	}
+	return func(v reflect.Value) (string, error) {
+		return strconv.FormatFloat(v.Float(), format, prec, bits), nil
+	}
 }
 
 func encodeString(v reflect.Value) (string, error) {
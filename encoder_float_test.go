@@ -0,0 +1,63 @@
+package schema
+
+import "testing"
+
+func TestEncodeFloatFieldOptions(t *testing.T) {
+	type S struct {
+		Price float64 `schema:"price,fmt:g,prec:-1"`
+		Plain float64 `schema:"plain"`
+	}
+
+	dst := map[string][]string{}
+	if err := NewEncoder().Encode(S{Price: 1.5, Plain: 1.5}, dst); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := dst["price"][0]; got != "1.5" {
+		t.Errorf("price = %q, want %q", got, "1.5")
+	}
+	if got := dst["plain"][0]; got != "1.500000" {
+		t.Errorf("plain = %q, want %q", got, "1.500000")
+	}
+}
+
+func TestEncodeGlobalFloatFormat(t *testing.T) {
+	type S struct {
+		Value float64 `schema:"value"`
+	}
+
+	enc := NewEncoder()
+	enc.SetFloatFormat('g', -1)
+
+	dst := map[string][]string{}
+	if err := enc.Encode(S{Value: 1.5}, dst); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := dst["value"][0]; got != "1.5" {
+		t.Errorf("value = %q, want %q", got, "1.5")
+	}
+}
+
+func TestEncodeIntBase(t *testing.T) {
+	type S struct {
+		Mask int `schema:"mask,base:16"`
+	}
+
+	dst := map[string][]string{}
+	if err := NewEncoder().Encode(S{Mask: 255}, dst); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := dst["mask"][0]; got != "ff" {
+		t.Errorf("mask = %q, want %q", got, "ff")
+	}
+}
+
+func TestEncodeInvalidIntBaseReportsError(t *testing.T) {
+	type S struct {
+		Mask int `schema:"mask,base:1"`
+	}
+
+	dst := map[string][]string{}
+	if err := NewEncoder().Encode(S{Mask: 255}, dst); err == nil {
+		t.Fatal("expected an error for out-of-range base")
+	}
+}
@@ -0,0 +1,45 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+type zeroable struct {
+	zero bool
+}
+
+func (z zeroable) IsZero() bool {
+	return z.zero
+}
+
+func TestIsZeroUsesZeroer(t *testing.T) {
+	if !isZero(reflect.ValueOf(zeroable{zero: true})) {
+		t.Error("expected zeroable{zero: true} to be zero")
+	}
+	if isZero(reflect.ValueOf(zeroable{zero: false})) {
+		t.Error("expected zeroable{zero: false} to not be zero")
+	}
+}
+
+func TestEncodeOmitemptyUsesZeroerForStructField(t *testing.T) {
+	type S struct {
+		Stamp zeroable `schema:"stamp,omitempty"`
+	}
+
+	dst := map[string][]string{}
+	if err := NewEncoder().Encode(S{Stamp: zeroable{zero: true}}, dst); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(dst) != 0 {
+		t.Errorf("dst = %v, want empty (zero Zeroer struct with omitempty should be dropped, not flattened)", dst)
+	}
+
+	dst = map[string][]string{}
+	if err := NewEncoder().Encode(S{Stamp: zeroable{zero: false}}, dst); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(dst) == 0 {
+		t.Error("expected a non-zero Zeroer struct to still be encoded")
+	}
+}
@@ -0,0 +1,28 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MultiError stores multiple decoding or encoding errors.
+//
+// Decoder errors are keyed by the source key of the field that produced
+// them (or by the unknown key itself); Encoder errors are keyed by the
+// field's Go type.
+type MultiError map[string]error
+
+func (e MultiError) Error() string {
+	keys := make([]string, 0, len(e))
+	for k := range e {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	msgs := make([]string, len(keys))
+	for i, k := range keys {
+		msgs[i] = fmt.Sprintf("%s: %s", k, e[k].Error())
+	}
+	return strings.Join(msgs, "; ")
+}
@@ -0,0 +1,36 @@
+package schema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMultiErrorJoinsAllEntriesSorted(t *testing.T) {
+	me := MultiError{
+		"b": errors.New("b failed"),
+		"a": errors.New("a failed"),
+	}
+
+	want := `a: a failed; b: b failed`
+	if got := me.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestDecodeMultiErrorReportsAllRequiredFields(t *testing.T) {
+	type S struct {
+		A string `schema:"a,required"`
+		B string `schema:"b,required"`
+	}
+
+	var s S
+	err := NewDecoder().Decode(&s, map[string][]string{})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, `"a" is required`) || !strings.Contains(msg, `"b" is required`) {
+		t.Errorf("Error() = %q, want both a and b reported", msg)
+	}
+}
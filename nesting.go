@@ -0,0 +1,37 @@
+package schema
+
+// NestingStyle controls how Encoder and Decoder represent nested struct
+// fields as flat keys.
+type NestingStyle int
+
+const (
+	// NestFlat encodes nested struct fields directly into the parent's key
+	// namespace, e.g. a field aliased "child" inside any parent becomes
+	// simply "child". This is the default and may collide when two nested
+	// structs share a field alias.
+	NestFlat NestingStyle = iota
+
+	// NestDotted encodes nested struct fields as "parent.child".
+	NestDotted
+
+	// NestBracketed encodes nested struct fields as "parent[child]",
+	// PHP/Rails-style.
+	NestBracketed
+)
+
+// nestedName builds the key used for a nested field named name whose
+// parent was encoded under prefix, according to style. An empty prefix
+// (top-level fields) always returns name unchanged.
+func nestedName(style NestingStyle, prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	switch style {
+	case NestDotted:
+		return prefix + "." + name
+	case NestBracketed:
+		return prefix + "[" + name + "]"
+	default:
+		return name
+	}
+}
@@ -0,0 +1,46 @@
+package schema
+
+import "testing"
+
+type nestedInner struct {
+	Name string `schema:"name"`
+}
+
+type nestedOuter struct {
+	Child nestedInner `schema:"child"`
+}
+
+func TestNestingStyles(t *testing.T) {
+	cases := []struct {
+		style NestingStyle
+		key   string
+	}{
+		{NestFlat, "name"},
+		{NestDotted, "child.name"},
+		{NestBracketed, "child[name]"},
+	}
+
+	for _, c := range cases {
+		enc := NewEncoder()
+		enc.SetNestingStyle(c.style)
+
+		dst := map[string][]string{}
+		if err := enc.Encode(nestedOuter{Child: nestedInner{Name: "hi"}}, dst); err != nil {
+			t.Fatalf("style %v: unexpected encode error: %v", c.style, err)
+		}
+		if got := dst[c.key]; len(got) != 1 || got[0] != "hi" {
+			t.Fatalf("style %v: dst[%q] = %v, want [hi]", c.style, c.key, got)
+		}
+
+		dec := NewDecoder()
+		dec.SetNestingStyle(c.style)
+
+		var out nestedOuter
+		if err := dec.Decode(&out, dst); err != nil {
+			t.Fatalf("style %v: unexpected decode error: %v", c.style, err)
+		}
+		if out.Child.Name != "hi" {
+			t.Errorf("style %v: Child.Name = %q, want %q", c.style, out.Child.Name, "hi")
+		}
+	}
+}
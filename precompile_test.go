@@ -0,0 +1,68 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+type benchStruct struct {
+	Name  string   `schema:"name"`
+	Age   int      `schema:"age"`
+	Tags  []string `schema:"tags"`
+	Price float64  `schema:"price"`
+}
+
+func TestPrecompileEncodeDecode(t *testing.T) {
+	enc := NewEncoder()
+	enc.Precompile(benchStruct{})
+
+	src := benchStruct{Name: "a", Age: 1, Tags: []string{"x", "y"}, Price: 1.5}
+	dst := map[string][]string{}
+	if err := enc.Encode(src, dst); err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+
+	dec := NewDecoder()
+	dec.Precompile(benchStruct{})
+
+	var out benchStruct
+	if err := dec.Decode(&out, dst); err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	if !reflect.DeepEqual(out, src) {
+		t.Errorf("out = %+v, want %+v", out, src)
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	enc := NewEncoder()
+	enc.Precompile(benchStruct{})
+	src := benchStruct{Name: "a", Age: 1, Tags: []string{"x", "y"}, Price: 1.5}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		dst := map[string][]string{}
+		if err := enc.Encode(src, dst); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	dec := NewDecoder()
+	dec.Precompile(benchStruct{})
+	src := map[string][]string{
+		"name":  {"a"},
+		"age":   {"1"},
+		"tags":  {"x", "y"},
+		"price": {"1.5"},
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var out benchStruct
+		if err := dec.Decode(&out, src); err != nil {
+			b.Fatal(err)
+		}
+	}
+}